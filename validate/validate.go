@@ -0,0 +1,248 @@
+// Package validate registers a "version" and a "version_range" custom
+// validation with github.com/go-playground/validator/v10, so struct fields
+// of type version.VersionString/version.VersionStrings (or plain strings
+// tagged validate:"version") are checked at bind time in web handlers.
+//
+// A tag param with more than one constraint must escape its commas with
+// validator's "0x2C" placeholder, e.g.
+// validate:"version=numeric0x2Cmin=1.2.00x2Cmax=2.0.0", since validator
+// splits the raw tag string on unescaped commas before a custom validation
+// ever sees it (see the comment above RegisterValidations).
+package validate
+
+import (
+	"reflect"
+	"regexp"
+	"strings"
+
+	ut "github.com/go-playground/universal-translator"
+	"github.com/go-playground/validator/v10"
+
+	"github.com/hclihn/unmarshal_json_by_parts/version"
+)
+
+// rangeClausePtn matches a single clause of a version_range expression, e.g.
+// ">=1.2.0", "<2.0.0", or "=1.0.0".
+var rangeClausePtn = regexp.MustCompile(`^(>=|<=|!=|>|<|=)\s*(.+)$`)
+
+// semVerPtn matches a SemVer 2.0.0 version string, for the "semver" kind.
+var semVerPtn = regexp.MustCompile(`^(?:0|[1-9]\d*)\.(?:0|[1-9]\d*)\.(?:0|[1-9]\d*)(?:-[0-9A-Za-z-]+(?:\.[0-9A-Za-z-]+)*)?(?:\+[0-9A-Za-z-]+(?:\.[0-9A-Za-z-]+)*)?$`)
+
+// Struct tags for "version" and "version_range" must escape any literal
+// comma in their param with validator's own "0x2C" placeholder (and a
+// literal pipe with "0x7C"), since validator.Validate splits the whole tag
+// string on unescaped commas before a custom validation ever sees its
+// param: validate:"version=numeric0x2Cmin=1.2.00x2Cmax=2.0.0" or
+// validate:"version_range=>=1.2.00x2C<2.0.0". Validator replaces the
+// placeholders with the real characters before calling FieldLevel.Param(),
+// so parseVersionParam and the version_range clause split below never see
+// the placeholders themselves, only the plain "," they decode to. Writing
+// the tag with a literal, unescaped comma (e.g.
+// validate:"version=numeric,min=1.2.0,max=2.0.0") does NOT work: validator
+// treats it as two (or three) separate, unrelated tags.
+
+// RegisterValidations registers the "version" and "version_range" custom
+// validations on v. Call it once per validator.Validate instance, typically
+// during application start-up.
+func RegisterValidations(v *validator.Validate) error {
+	if err := v.RegisterValidation("version", validateVersion); err != nil {
+		return err
+	}
+	if err := v.RegisterValidation("version_range", validateVersionRange); err != nil {
+		return err
+	}
+	return nil
+}
+
+// RegisterTranslations wires English translations for the "version" and
+// "version_range" validations into trans, so validator.FieldError.Translate
+// returns messages like "Version must be >= 1.2.0" instead of the generic
+// "failed on the 'version' tag".
+func RegisterTranslations(v *validator.Validate, trans ut.Translator) error {
+	registerFn := func(ut ut.Translator) error {
+		if err := ut.Add("version", "{0} must be a valid version", true); err != nil {
+			return err
+		}
+		if err := ut.Add("version_semver", "{0} must be a valid SemVer version", true); err != nil {
+			return err
+		}
+		if err := ut.Add("version_min", "{0} must be >= {1}", true); err != nil {
+			return err
+		}
+		if err := ut.Add("version_max", "{0} must be <= {1}", true); err != nil {
+			return err
+		}
+		if err := ut.Add("version_between", "{0} must be between {1} and {2}", true); err != nil {
+			return err
+		}
+		if err := ut.Add("version_range", "{0} must satisfy {1}", true); err != nil {
+			return err
+		}
+		return nil
+	}
+	if err := v.RegisterTranslation("version", trans, registerFn, func(ut ut.Translator, fe validator.FieldError) string {
+		kind, constraints := parseVersionParam(fe.Param())
+		switch {
+		case constraints["min"] != "" && constraints["max"] != "":
+			t, _ := ut.T("version_between", fe.Field(), constraints["min"], constraints["max"])
+			return t
+		case constraints["min"] != "":
+			t, _ := ut.T("version_min", fe.Field(), constraints["min"])
+			return t
+		case constraints["max"] != "":
+			t, _ := ut.T("version_max", fe.Field(), constraints["max"])
+			return t
+		case kind == "semver":
+			t, _ := ut.T("version_semver", fe.Field())
+			return t
+		default:
+			t, _ := ut.T("version", fe.Field())
+			return t
+		}
+	}); err != nil {
+		return err
+	}
+	return v.RegisterTranslation("version_range", trans, registerFn, func(ut ut.Translator, fe validator.FieldError) string {
+		t, _ := ut.T("version_range", fe.Field(), fe.Param())
+		return t
+	})
+}
+
+// parseVersionParam splits an already-unescaped "version" tag parameter
+// (e.g. "numeric,min=1.2.0,max=2.0.0", decoded from the struct tag's
+// "numeric0x2Cmin=1.2.00x2Cmax=2.0.0") into its format kind ("semver",
+// "numeric", or "" for unspecified) and its key=value constraints.
+func parseVersionParam(param string) (kind string, constraints map[string]string) {
+	constraints = map[string]string{}
+	parts := strings.Split(param, ",")
+	if len(parts) > 0 && parts[0] != "" && !strings.Contains(parts[0], "=") {
+		kind = parts[0]
+		parts = parts[1:]
+	}
+	for _, p := range parts {
+		if p == "" {
+			continue
+		}
+		if kv := strings.SplitN(p, "=", 2); len(kv) == 2 {
+			constraints[kv[0]] = kv[1]
+		}
+	}
+	return kind, constraints
+}
+
+// versionsOf extracts the raw version strings held by a string,
+// version.VersionString, or version.VersionStrings field.
+func versionsOf(fv reflect.Value) ([]string, bool) {
+	switch val := fv.Interface().(type) {
+	case string:
+		return []string{val}, true
+	case version.VersionString:
+		return []string{val.Version}, true
+	case version.VersionStrings:
+		out := make([]string, len(val))
+		for i, v := range val {
+			out[i] = v.Version
+		}
+		return out, true
+	default:
+		return nil, false
+	}
+}
+
+func validateVersion(fl validator.FieldLevel) bool {
+	vals, ok := versionsOf(fl.Field())
+	if !ok || len(vals) == 0 {
+		return false
+	}
+	kind, constraints := parseVersionParam(fl.Param())
+	var minV, maxV version.VersionString
+	if s := constraints["min"]; s != "" {
+		if err := minV.FromString(s); err != nil {
+			return false
+		}
+	}
+	if s := constraints["max"]; s != "" {
+		if err := maxV.FromString(s); err != nil {
+			return false
+		}
+	}
+	for _, s := range vals {
+		var v version.VersionString
+		if err := v.FromString(s); err != nil {
+			return false
+		}
+		if kind == "semver" && !semVerPtn.MatchString(v.Version) {
+			return false
+		}
+		if kind == "numeric" {
+			for _, f := range v.Fields {
+				if f.IsStr {
+					return false
+				}
+			}
+		}
+		if constraints["min"] != "" && v.Less(minV) {
+			return false
+		}
+		if constraints["max"] != "" && maxV.Less(v) {
+			return false
+		}
+	}
+	return true
+}
+
+func validateVersionRange(fl validator.FieldLevel) bool {
+	vals, ok := versionsOf(fl.Field())
+	if !ok || len(vals) == 0 {
+		return false
+	}
+	clauses := strings.Split(fl.Param(), ",")
+	for _, s := range vals {
+		var v version.VersionString
+		if err := v.FromString(s); err != nil {
+			return false
+		}
+		for _, c := range clauses {
+			c = strings.TrimSpace(c)
+			if c == "" {
+				continue
+			}
+			m := rangeClausePtn.FindStringSubmatch(c)
+			if m == nil {
+				return false
+			}
+			var bound version.VersionString
+			if err := bound.FromString(strings.TrimSpace(m[2])); err != nil {
+				return false
+			}
+			cmp := v.Compare(bound)
+			switch m[1] {
+			case ">=":
+				if cmp < 0 {
+					return false
+				}
+			case "<=":
+				if cmp > 0 {
+					return false
+				}
+			case ">":
+				if cmp <= 0 {
+					return false
+				}
+			case "<":
+				if cmp >= 0 {
+					return false
+				}
+			case "=":
+				if cmp != 0 {
+					return false
+				}
+			case "!=":
+				if cmp == 0 {
+					return false
+				}
+			}
+		}
+	}
+	return true
+}
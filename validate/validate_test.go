@@ -0,0 +1,161 @@
+package validate
+
+import (
+	"testing"
+
+	en_locale "github.com/go-playground/locales/en"
+	ut "github.com/go-playground/universal-translator"
+	"github.com/go-playground/validator/v10"
+)
+
+func newValidator(t *testing.T) *validator.Validate {
+	t.Helper()
+	v := validator.New()
+	if err := RegisterValidations(v); err != nil {
+		t.Fatalf("RegisterValidations: %v", err)
+	}
+	return v
+}
+
+// newValidatorWithEnglish returns a validator wired up with RegisterTranslations
+// and an English universal-translator instance, for asserting on
+// FieldError.Translate output.
+func newValidatorWithEnglish(t *testing.T) (*validator.Validate, ut.Translator) {
+	t.Helper()
+	v := newValidator(t)
+	en := en_locale.New()
+	uni := ut.New(en, en)
+	trans, _ := uni.GetTranslator("en")
+	if err := RegisterTranslations(v, trans); err != nil {
+		t.Fatalf("RegisterTranslations: %v", err)
+	}
+	return v, trans
+}
+
+func TestValidateVersionKindAndBounds(t *testing.T) {
+	type target struct {
+		V string `validate:"version=numeric0x2Cmin=1.2.00x2Cmax=2.0.0"`
+	}
+	v := newValidator(t)
+
+	for _, s := range []string{"1.2.0", "1.5.9", "2.0.0"} {
+		if err := v.Struct(target{V: s}); err != nil {
+			t.Errorf("%q: unexpected error: %v", s, err)
+		}
+	}
+	for _, s := range []string{"1.1.9", "2.0.1", "1.5.9-rc.1", "1.5.9a"} {
+		if err := v.Struct(target{V: s}); err == nil {
+			t.Errorf("%q: expected a validation error, got none", s)
+		}
+	}
+}
+
+func TestValidateVersionSemverKind(t *testing.T) {
+	type target struct {
+		V string `validate:"version=semver"`
+	}
+	v := newValidator(t)
+
+	if err := v.Struct(target{V: "1.2.3-rc.1+build5"}); err != nil {
+		t.Errorf("unexpected error for a valid SemVer string: %v", err)
+	}
+	if err := v.Struct(target{V: "1.2.3.4"}); err == nil {
+		t.Error("expected an error for a non-SemVer dotted version")
+	}
+}
+
+func TestValidateVersionNumericKindRejectsMixedFields(t *testing.T) {
+	type target struct {
+		V string `validate:"version=numeric"`
+	}
+	v := newValidator(t)
+
+	if err := v.Struct(target{V: "1.2.3.4"}); err != nil {
+		t.Errorf("unexpected error for a purely numeric version: %v", err)
+	}
+	for _, s := range []string{"1.2.3a", "1.2.3-rc.1"} {
+		if err := v.Struct(target{V: s}); err == nil {
+			t.Errorf("%q: expected the numeric kind to reject a non-purely-numeric field", s)
+		}
+	}
+}
+
+func TestValidateVersionRange(t *testing.T) {
+	type target struct {
+		V string `validate:"version_range=>=1.2.00x2C<2.0.0"`
+	}
+	v := newValidator(t)
+
+	for _, s := range []string{"1.2.0", "1.9.9"} {
+		if err := v.Struct(target{V: s}); err != nil {
+			t.Errorf("%q: unexpected error: %v", s, err)
+		}
+	}
+	for _, s := range []string{"1.1.9", "2.0.0"} {
+		if err := v.Struct(target{V: s}); err == nil {
+			t.Errorf("%q: expected a validation error, got none", s)
+		}
+	}
+}
+
+// translate runs v.Struct(target) and returns the translated message for the
+// first field error, failing the test if validation unexpectedly passed.
+func translate(t *testing.T, v *validator.Validate, trans ut.Translator, target interface{}) string {
+	t.Helper()
+	err := v.Struct(target)
+	if err == nil {
+		t.Fatal("expected a validation error, got none")
+	}
+	fes, ok := err.(validator.ValidationErrors)
+	if !ok || len(fes) == 0 {
+		t.Fatalf("expected validator.ValidationErrors, got %T: %v", err, err)
+	}
+	return fes[0].Translate(trans)
+}
+
+func TestRegisterTranslationsMinMaxBetween(t *testing.T) {
+	v, trans := newValidatorWithEnglish(t)
+
+	type minTarget struct {
+		V string `validate:"version=min=1.2.0"`
+	}
+	if got, want := translate(t, v, trans, minTarget{V: "1.0.0"}), "V must be >= 1.2.0"; got != want {
+		t.Errorf("min: got %q, want %q", got, want)
+	}
+
+	type maxTarget struct {
+		V string `validate:"version=max=1.2.0"`
+	}
+	if got, want := translate(t, v, trans, maxTarget{V: "2.0.0"}), "V must be <= 1.2.0"; got != want {
+		t.Errorf("max: got %q, want %q", got, want)
+	}
+
+	type betweenTarget struct {
+		V string `validate:"version=min=1.2.00x2Cmax=2.0.0"`
+	}
+	if got, want := translate(t, v, trans, betweenTarget{V: "3.0.0"}), "V must be between 1.2.0 and 2.0.0"; got != want {
+		t.Errorf("between: got %q, want %q", got, want)
+	}
+}
+
+func TestRegisterTranslationsSemver(t *testing.T) {
+	v, trans := newValidatorWithEnglish(t)
+
+	type target struct {
+		V string `validate:"version=semver"`
+	}
+	if got, want := translate(t, v, trans, target{V: "1.2.3.4"}), "V must be a valid SemVer version"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestRegisterTranslationsVersionRange(t *testing.T) {
+	v, trans := newValidatorWithEnglish(t)
+
+	type target struct {
+		V string `validate:"version_range=>=1.2.00x2C<2.0.0"`
+	}
+	if got, want := translate(t, v, trans, target{V: "1.0.0"}), "V must satisfy >=1.2.0,<2.0.0"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,581 @@
+// Package version implements parsing, comparison, and JSON
+// marshaling/unmarshaling of dotted and SemVer 2.0.0 version strings.
+package version
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var SimpleStringUnmarshalForVersionString = false
+
+// MarshalMode controls how VersionString/VersionStrings render to JSON.
+type MarshalMode int
+
+const (
+	// Full marshals the complete struct/array form (the default), with each
+	// VersionField rendered as a bare JSON number or string.
+	Full MarshalMode = iota
+	// Compact marshals just the canonical version string(s), falling back
+	// to Full for any value that would lose information on the round trip.
+	Compact
+)
+
+// VersionMarshalMode is the package-level switch selecting the JSON
+// representation used by VersionString.MarshalJSON and
+// VersionStrings.MarshalJSON.
+var VersionMarshalMode = Full
+
+// semVerPtn matches a SemVer 2.0.0 version string, capturing the numeric
+// MAJOR.MINOR.PATCH core, the optional pre-release identifiers, and the
+// optional build metadata.
+var semVerPtn = regexp.MustCompile(`^(?:0|[1-9]\d*)\.(?:0|[1-9]\d*)\.(?:0|[1-9]\d*)(-[0-9A-Za-z-]+(?:\.[0-9A-Za-z-]+)*)?(\+[0-9A-Za-z-]+(?:\.[0-9A-Za-z-]+)*)?$`)
+
+// numericIdentifierPtn matches a SemVer numeric pre-release identifier: all
+// digits, with no leading zero (unless it is the single digit "0").
+var numericIdentifierPtn = regexp.MustCompile(`^(0|[1-9]\d*)$`)
+
+// digitsOnlyPtn matches a version component made up entirely of decimal
+// digits (allowing leading zeros, unlike numericIdentifierPtn).
+var digitsOnlyPtn = regexp.MustCompile(`^\d+$`)
+
+// naturalRunPtn splits a string into runs of digits and runs of non-digits,
+// for natural-sort comparison of non-SemVer version components.
+var naturalRunPtn = regexp.MustCompile(`\d+|\D+`)
+
+func WrapTraceableErrorf(err error, fs string, args ...interface{}) error {
+	s := fmt.Sprintf(fs, args...)
+	return fmt.Errorf("%s: %w", s, err)
+}
+
+// VersionField holds a single dot-separated version component. A
+// purely-numeric component is stored in NumValue, or in BigValue if it
+// overflows a uint64; anything else (a SemVer string pre-release
+// identifier, or a mixed alphanumeric component such as Debian's "3a") is
+// kept verbatim in StrValue with IsStr set.
+type VersionField struct {
+	IsStr    bool     // Is a string field? Determines which of NumValue/BigValue/StrValue holds the value
+	NumValue uint64   // numerical value, valid when !IsStr and BigValue is nil
+	BigValue *big.Int // numerical value that overflows a uint64, valid when !IsStr and non-nil
+	StrValue string   // string value (ordered string), valid when IsStr
+}
+
+// parseDigits converts a string of decimal digits (already validated by the
+// caller) into a VersionField, promoting to BigValue when the value
+// overflows a uint64.
+func parseDigits(s string) VersionField {
+	if x, err := strconv.ParseUint(s, 10, 64); err == nil {
+		return VersionField{NumValue: x}
+	}
+	bi, _ := new(big.Int).SetString(s, 10) // digit-only input, so this always succeeds
+	return VersionField{BigValue: bi}
+}
+
+// parseVersionField parses a single dot-separated version component: a
+// purely-numeric component, leading zeros included (e.g. CalVer "01"),
+// becomes a numeric field (see parseDigits); anything else (e.g. Debian
+// "3a") is kept verbatim as a string field.
+func parseVersionField(s string) VersionField {
+	if !digitsOnlyPtn.MatchString(s) {
+		return VersionField{IsStr: true, StrValue: s}
+	}
+	return parseDigits(s)
+}
+
+// fieldBig returns f's numerical value as a big.Int. It must only be called
+// on a non-string field.
+func fieldBig(f VersionField) *big.Int {
+	if f.BigValue != nil {
+		return f.BigValue
+	}
+	return new(big.Int).SetUint64(f.NumValue)
+}
+
+// fieldToken returns f's textual token, for natural-sort comparison: the
+// verbatim string of a string field, or the decimal value of a numeric field
+// (without any leading zeros the original component had).
+func fieldToken(f VersionField) string {
+	if f.IsStr {
+		return f.StrValue
+	}
+	return fieldBig(f).String()
+}
+
+// naturalCompare compares a and b using natural-sort order: the strings are
+// split into runs of digits and runs of non-digits, digit runs are compared
+// as big integers, and non-digit runs are compared lexically. A sequence
+// that runs out of runs first sorts before the other.
+func naturalCompare(a, b string) int {
+	as, bs := naturalRunPtn.FindAllString(a, -1), naturalRunPtn.FindAllString(b, -1)
+	n := len(as)
+	if len(bs) < n {
+		n = len(bs)
+	}
+	for i := 0; i < n; i++ {
+		x, xIsNum := new(big.Int).SetString(as[i], 10)
+		y, yIsNum := new(big.Int).SetString(bs[i], 10)
+		switch {
+		case xIsNum && yIsNum:
+			if c := x.Cmp(y); c != 0 {
+				return c
+			}
+		case xIsNum: // a digit run sorts before a non-digit run
+			return -1
+		case yIsNum:
+			return 1
+		default:
+			if c := strings.Compare(as[i], bs[i]); c != 0 {
+				return c
+			}
+		}
+	}
+	return len(as) - len(bs)
+}
+
+// MarshalJSON renders f as a bare JSON number (e.g. 3 or
+// 12345678901234567890) or string (e.g. "rc") instead of the full
+// {"IsStr":...} struct.
+func (f VersionField) MarshalJSON() ([]byte, error) {
+	switch {
+	case f.IsStr:
+		return json.Marshal(f.StrValue)
+	case f.BigValue != nil:
+		return f.BigValue.MarshalJSON()
+	default:
+		return json.Marshal(f.NumValue)
+	}
+}
+
+// UnmarshalJSON parses a bare JSON number or string into f. It decodes via
+// json.Decoder.UseNumber so an arbitrarily large integer is preserved
+// exactly in BigValue instead of being silently coerced to a lossy float64.
+func (f *VersionField) UnmarshalJSON(b []byte) error {
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	var raw interface{}
+	if err := dec.Decode(&raw); err != nil {
+		return WrapTraceableErrorf(err, "failed to decode JSON token for VersionField")
+	}
+	switch t := raw.(type) {
+	case json.Number:
+		if x, err := strconv.ParseUint(t.String(), 10, 64); err == nil {
+			*f = VersionField{NumValue: x}
+			return nil
+		}
+		bi, ok := new(big.Int).SetString(t.String(), 10)
+		if !ok {
+			return WrapTraceableErrorf(nil, "failed to parse large numeric VersionField %q", t.String())
+		}
+		*f = VersionField{BigValue: bi}
+	case string:
+		*f = VersionField{IsStr: true, StrValue: t}
+	default:
+		return WrapTraceableErrorf(nil, "unexpected JSON type %T for VersionField", raw)
+	}
+	return nil
+}
+
+// VersionString represents a version string
+type VersionString struct {
+	Version        string         // raw version
+	Fields         []VersionField // version fields, one per dot-separated component; non-numeric components are kept as string fields (IsStr), uint64 overflows in BigValue
+	NumCoreFields  int            // number of leading Fields that make up MAJOR.MINOR.PATCH (or a plain dotted version); the rest are SemVer pre-release identifiers
+	Build          string         // SemVer build metadata (without the leading '+'), ignored for ordering
+	OrderedVersion bool           // treat the Version as ordered string?
+}
+
+// FromString parses s as either a SemVer 2.0.0 string
+// (MAJOR.MINOR.PATCH[-PRERELEASE][+BUILD]) or, failing that, a plain
+// dot-separated list of components (e.g. "1.2.3.4" or "1.2.3a"), populating
+// Fields accordingly. Only an empty (or all-space) s is rejected.
+func (v *VersionString) FromString(s string) error {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return WrapTraceableErrorf(nil, "empty version string specified")
+	}
+	v.Version = s
+	v.Fields = nil
+	v.NumCoreFields = 0
+	v.Build = ""
+	v.OrderedVersion = false
+
+	if m := semVerPtn.FindStringSubmatch(s); m != nil {
+		core := s
+		if i := strings.IndexAny(s, "-+"); i >= 0 {
+			core = s[:i]
+		}
+		coreFields := strings.Split(core, ".")
+		ver := make([]VersionField, 0, len(coreFields))
+		for _, f := range coreFields { // semVerPtn guarantees each core field is digit-only
+			ver = append(ver, parseDigits(f))
+		}
+		v.NumCoreFields = len(ver)
+		if pre := m[1]; pre != "" {
+			for _, seg := range strings.Split(strings.TrimPrefix(pre, "-"), ".") {
+				if numericIdentifierPtn.MatchString(seg) {
+					ver = append(ver, parseDigits(seg))
+				} else {
+					ver = append(ver, VersionField{IsStr: true, StrValue: seg})
+				}
+			}
+		}
+		if build := m[2]; build != "" {
+			v.Build = strings.TrimPrefix(build, "+")
+		}
+		v.Fields = ver
+		return nil
+	}
+
+	// not a SemVer string, fall back to a plain dot-separated version; each
+	// component may be purely numeric (e.g. "1.2.3.4", CalVer "2024.01",
+	// Windows "10.0.19045.3803"), overflow a uint64, or mix letters in (e.g.
+	// Debian "1.2.3a"), the latter compared via natural sort.
+	fields := strings.Split(s, ".")
+	ver := make([]VersionField, len(fields))
+	for i, f := range fields {
+		ver[i] = parseVersionField(f)
+	}
+	v.Fields = ver
+	v.NumCoreFields = len(ver)
+	return nil
+}
+
+// compareVersionField compares two plain (non-pre-release) fields. Purely
+// numeric fields (including ones that overflowed into BigValue) are
+// compared numerically; if either field is non-purely-numeric, both are
+// compared via natural sort instead.
+func compareVersionField(a, b VersionField) int {
+	if a.IsStr || b.IsStr {
+		return naturalCompare(fieldToken(a), fieldToken(b))
+	}
+	return fieldBig(a).Cmp(fieldBig(b))
+}
+
+// comparePreReleaseField compares two SemVer pre-release identifiers: numeric
+// identifiers are compared as integers, string identifiers are compared
+// lexically, and a numeric identifier always has lower precedence than a
+// string identifier.
+func comparePreReleaseField(a, b VersionField) int {
+	switch {
+	case !a.IsStr && !b.IsStr:
+		return compareVersionField(a, b)
+	case a.IsStr && b.IsStr:
+		return strings.Compare(a.StrValue, b.StrValue)
+	case a.IsStr:
+		return 1
+	default:
+		return -1
+	}
+}
+
+// Compare returns a negative number, zero, or a positive number if v is less
+// than, equal to, or greater than other, following SemVer 2.0.0 precedence rules. If either version has
+// OrderedVersion set, it falls back to plain lexical comparison of Version.
+func (v VersionString) Compare(other VersionString) int {
+	if v.OrderedVersion || other.OrderedVersion {
+		return strings.Compare(v.Version, other.Version)
+	}
+	n := v.NumCoreFields
+	if other.NumCoreFields < n {
+		n = other.NumCoreFields
+	}
+	for i := 0; i < n; i++ {
+		if c := compareVersionField(v.Fields[i], other.Fields[i]); c != 0 {
+			return c
+		}
+	}
+	if v.NumCoreFields != other.NumCoreFields {
+		return v.NumCoreFields - other.NumCoreFields
+	}
+
+	vPre, oPre := v.Fields[v.NumCoreFields:], other.Fields[other.NumCoreFields:]
+	switch {
+	case len(vPre) == 0 && len(oPre) == 0:
+		return 0
+	case len(vPre) == 0: // a version without a pre-release outranks one with
+		return 1
+	case len(oPre) == 0:
+		return -1
+	}
+	m := len(vPre)
+	if len(oPre) < m {
+		m = len(oPre)
+	}
+	for i := 0; i < m; i++ {
+		if c := comparePreReleaseField(vPre[i], oPre[i]); c != 0 {
+			return c
+		}
+	}
+	return len(vPre) - len(oPre)
+}
+
+// Less reports whether v sorts before other.
+func (v VersionString) Less(other VersionString) bool {
+	return v.Compare(other) < 0
+}
+
+// Equal reports whether v and other compare as equal.
+func (v VersionString) Equal(other VersionString) bool {
+	return v.Compare(other) == 0
+}
+
+// versionStringAlias has the same fields as VersionString but none of its
+// methods, so it can be marshaled/unmarshaled via the default struct codec
+// without recursing back into VersionString's own MarshalJSON/UnmarshalJSON.
+type versionStringAlias VersionString
+
+func (v *VersionString) UnmarshalJSON(b []byte) error {
+	if string(b) == "null" { // null version
+		v.Version = ""
+		v.Fields = nil
+		v.NumCoreFields = 0
+		v.Build = ""
+		v.OrderedVersion = false
+		return nil
+	}
+	if b[0] == '"' { // backward compatibility support  for a pure string value
+		if !SimpleStringUnmarshalForVersionString {
+			return WrapTraceableErrorf(nil, "plain string form not allowed for VersionString, enable SimpleStringUnmarshalForVersionString or use the full object form")
+		}
+		s := strings.Trim(string(b), "\"") // covers "" and actual string values
+		if s == "" {                       // empty version
+			v.Version = ""
+			v.Fields = nil
+			v.NumCoreFields = 0
+			v.Build = ""
+			v.OrderedVersion = false
+			return nil
+		}
+		// simple version
+		return v.FromString(s)
+	}
+	// unmarshal its fields one at a time. If we call Unmarshal(v), we will get infinite loops
+	dec := json.NewDecoder(bytes.NewReader(b))
+	// read the open brace
+	if t, err := dec.Token(); err != nil {
+		return WrapTraceableErrorf(err, "failed to decode JSON token for VersionString")
+	} else if d, ok := t.(json.Delim); !ok {
+		return WrapTraceableErrorf(nil, "expected a JSON delimiter for VersionString, got %T (%s)", t, t)
+	} else if d != '{' {
+		return WrapTraceableErrorf(nil, "bad JSON delimiter '%s' for VersionString, expected '{'", t)
+	}
+
+	// while the object contains values
+	for dec.More() {
+		if t, err := dec.Token(); err != nil {
+			return WrapTraceableErrorf(err, "failed to decode JSON token for VersionString")
+		} else if ts, ok := t.(string); !ok {
+			return WrapTraceableErrorf(nil, "bad JSON token %T (%s) for VersionString field name", t, t)
+		} else {
+			switch ts { // unmarshal individual field
+			case "Version":
+				var s string
+				if err := dec.Decode(&s); err != nil {
+					return err
+				}
+				v.Version = s
+			case "Fields":
+				var f []VersionField
+				if err := dec.Decode(&f); err != nil {
+					return err
+				}
+				v.Fields = f
+			case "NumCoreFields":
+				var n int
+				if err := dec.Decode(&n); err != nil {
+					return err
+				}
+				v.NumCoreFields = n
+			case "Build":
+				var s string
+				if err := dec.Decode(&s); err != nil {
+					return err
+				}
+				v.Build = s
+			case "OrderedVersion":
+				var b bool
+				if err := dec.Decode(&b); err != nil {
+					return err
+				}
+				v.OrderedVersion = b
+			default:
+				return WrapTraceableErrorf(nil, "unknown field name %q for VersionString type", ts)
+			}
+		}
+	}
+
+	// read the closing brace
+	if t, err := dec.Token(); err != nil {
+		return WrapTraceableErrorf(err, "failed to decode JSON token for VersionString")
+	} else if d, ok := t.(json.Delim); !ok {
+		return WrapTraceableErrorf(nil, "expected a JSON delimiter for VersionString, got %T (%s)", t, t)
+	} else if d != '}' {
+		return WrapTraceableErrorf(nil, "bad JSON delimiter '%s' for VersionString, expected '}'", t)
+	}
+	return nil
+}
+
+// canMarshalCompact reports whether v can be written as a plain JSON string
+// and read back via UnmarshalJSON without losing information.
+func (v VersionString) canMarshalCompact() bool {
+	return SimpleStringUnmarshalForVersionString && v.Version != "" && !v.OrderedVersion
+}
+
+// MarshalJSON honors VersionMarshalMode: Compact emits the canonical version
+// string (e.g. "1.2.3-rc.1+build5") whenever that loses no information,
+// falling back to Full (the struct form) otherwise.
+func (v VersionString) MarshalJSON() ([]byte, error) {
+	if VersionMarshalMode == Compact && v.canMarshalCompact() {
+		return json.Marshal(v.Version)
+	}
+	return json.Marshal(versionStringAlias(v))
+}
+
+type VersionStrings []VersionString
+
+// sort.Interface implementation, ordering by VersionString.Compare.
+func (vs VersionStrings) Len() int           { return len(vs) }
+func (vs VersionStrings) Swap(i, j int)      { vs[i], vs[j] = vs[j], vs[i] }
+func (vs VersionStrings) Less(i, j int) bool { return vs[i].Less(vs[j]) }
+
+// Sort sorts vs in place using VersionString.Compare.
+func (vs VersionStrings) Sort() {
+	sort.Sort(vs)
+}
+
+func (vs *VersionStrings) FromString(s string) error {
+	fields := strings.Split(s, ";")
+	if len(fields) == 0 { // empty
+		*vs = nil
+		return nil
+	}
+	vs1 := make(VersionStrings, len(fields))
+	for i := range vs1 {
+		if err := vs1[i].FromString(fields[i]); err != nil {
+			return WrapTraceableErrorf(err, "empty or malformed version string part[%d] %q in %q", i, fields[i], s)
+		}
+	}
+	*vs = vs1
+	return nil
+}
+
+func (vs *VersionStrings) UnmarshalJSON(b []byte) error {
+	if string(b) == "null" { // null version
+		*vs = nil
+		return nil
+	}
+	if b[0] == '"' { // backward compatibility support  for a pure string value
+		if !SimpleStringUnmarshalForVersionString {
+			return WrapTraceableErrorf(nil, "plain string form not allowed for VersionStrings, enable SimpleStringUnmarshalForVersionString or use the full array form")
+		}
+		s := strings.Trim(string(b), "\"") // covers "" and actual string values
+		if s == "" {                       // empty version
+			*vs = nil
+			return nil
+		}
+		// simple version
+		return vs.FromString(s)
+	}
+	// unmarshal its fields one at a time. If we call Unmarshal(v), we will get infinite loops
+	dec := json.NewDecoder(bytes.NewReader(b))
+	// read the open brace
+	if t, err := dec.Token(); err != nil {
+		return WrapTraceableErrorf(err, "failed to decode JSON token for VersionString")
+	} else if d, ok := t.(json.Delim); !ok {
+		return WrapTraceableErrorf(nil, "expected a JSON delimiter for VersionString, got %T (%s)", t, t)
+	} else if d != '[' {
+		return WrapTraceableErrorf(nil, "bad JSON delimiter '%s' for VersionString, expected '['", t)
+	}
+
+	// while the object contains values
+	vs1 := make(VersionStrings, 0)
+	for dec.More() {
+		var v VersionString
+		if err := dec.Decode(&v); err != nil {
+			return err
+		}
+		vs1 = append(vs1, v)
+	}
+
+	// read the closing brace
+	if t, err := dec.Token(); err != nil {
+		return WrapTraceableErrorf(err, "failed to decode JSON token for VersionString")
+	} else if d, ok := t.(json.Delim); !ok {
+		return WrapTraceableErrorf(nil, "expected a JSON delimiter for VersionString, got %T (%s)", t, t)
+	} else if d != ']' {
+		return WrapTraceableErrorf(nil, "bad JSON delimiter '%s' for VersionString, expected ']'", t)
+	}
+	if len(vs1) > 0 {
+		*vs = vs1
+	} else {
+		*vs = nil
+	}
+	return nil
+}
+
+// MarshalJSON honors VersionMarshalMode: Compact emits a single
+// semicolon-joined string (e.g. "1.2.3;0.1.2") mirroring FromString,
+// falling back to Full (a JSON array) for any element that would lose
+// information on the round trip.
+func (vs VersionStrings) MarshalJSON() ([]byte, error) {
+	if VersionMarshalMode == Compact {
+		parts := make([]string, len(vs))
+		compact := true
+		for i, v := range vs {
+			if !v.canMarshalCompact() {
+				compact = false
+				break
+			}
+			parts[i] = v.Version
+		}
+		if compact {
+			return json.Marshal(strings.Join(parts, ";"))
+		}
+	}
+	type versionStringsAlias VersionStrings
+	return json.Marshal(versionStringsAlias(vs))
+}
+
+// DecodeVersionStream reads a JSON array of VersionString values from r one
+// element at a time, invoking fn for each one, without ever buffering the
+// whole array in memory. It is meant for large version manifests that are
+// impractical to unmarshal via VersionStrings.UnmarshalJSON.
+func DecodeVersionStream(r io.Reader, fn func(VersionString) error) error {
+	dec := json.NewDecoder(r)
+	// read the open bracket
+	if t, err := dec.Token(); err != nil {
+		return WrapTraceableErrorf(err, "failed to decode JSON token for VersionString stream")
+	} else if d, ok := t.(json.Delim); !ok {
+		return WrapTraceableErrorf(nil, "expected a JSON delimiter for VersionString stream, got %T (%s)", t, t)
+	} else if d != '[' {
+		return WrapTraceableErrorf(nil, "bad JSON delimiter '%s' for VersionString stream, expected '['", t)
+	}
+
+	// while the array contains values
+	for i := 0; dec.More(); i++ {
+		var v VersionString
+		if err := dec.Decode(&v); err != nil {
+			return WrapTraceableErrorf(err, "failed to decode VersionString at index %d", i)
+		}
+		if err := fn(v); err != nil {
+			return WrapTraceableErrorf(err, "callback failed for VersionString at index %d", i)
+		}
+	}
+
+	// read the closing bracket
+	if t, err := dec.Token(); err != nil {
+		return WrapTraceableErrorf(err, "failed to decode JSON token for VersionString stream")
+	} else if d, ok := t.(json.Delim); !ok {
+		return WrapTraceableErrorf(nil, "expected a JSON delimiter for VersionString stream, got %T (%s)", t, t)
+	} else if d != ']' {
+		return WrapTraceableErrorf(nil, "bad JSON delimiter '%s' for VersionString stream, expected ']'", t)
+	}
+	return nil
+}
@@ -0,0 +1,312 @@
+package version
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"testing"
+)
+
+func TestFromStringOverflow(t *testing.T) {
+	var v VersionString
+	if err := v.FromString("99999999999999999999.2.3"); err != nil {
+		t.Fatalf("FromString: %v", err)
+	}
+	if v.Fields[0].IsStr || v.Fields[0].BigValue == nil {
+		t.Fatalf("expected field #0 to overflow into BigValue, got %+v", v.Fields[0])
+	}
+	want, _ := new(big.Int).SetString("99999999999999999999", 10)
+	if v.Fields[0].BigValue.Cmp(want) != 0 {
+		t.Errorf("BigValue = %s, want %s", v.Fields[0].BigValue, want)
+	}
+
+	var bigger VersionString
+	if err := bigger.FromString("100000000000000000000.0.0"); err != nil {
+		t.Fatalf("FromString: %v", err)
+	}
+	if !v.Less(bigger) {
+		t.Errorf("expected %q < %q", v.Version, bigger.Version)
+	}
+}
+
+func TestFromStringLeadingZeros(t *testing.T) {
+	var v VersionString
+	if err := v.FromString("2024.01"); err != nil {
+		t.Fatalf("FromString: %v", err)
+	}
+	var v2 VersionString
+	if err := v2.FromString("2024.1"); err != nil {
+		t.Fatalf("FromString: %v", err)
+	}
+	if !v.Equal(v2) {
+		t.Errorf("%q and %q should compare equal", v.Version, v2.Version)
+	}
+}
+
+func TestFromStringMixedAlphanumeric(t *testing.T) {
+	for _, s := range []string{"1.2.3a", "10.0.19045.3803"} {
+		var v VersionString
+		if err := v.FromString(s); err != nil {
+			t.Fatalf("FromString(%q): %v", s, err)
+		}
+	}
+
+	var lower, higher VersionString
+	if err := lower.FromString("1.2.3a"); err != nil {
+		t.Fatalf("FromString: %v", err)
+	}
+	if err := higher.FromString("1.2.3b"); err != nil {
+		t.Fatalf("FromString: %v", err)
+	}
+	if !lower.Less(higher) {
+		t.Errorf("expected %q < %q", lower.Version, higher.Version)
+	}
+
+	var ten, nine VersionString
+	if err := ten.FromString("1.2.10a"); err != nil {
+		t.Fatalf("FromString: %v", err)
+	}
+	if err := nine.FromString("1.2.9a"); err != nil {
+		t.Fatalf("FromString: %v", err)
+	}
+	if !nine.Less(ten) {
+		t.Errorf("expected %q < %q (natural sort, not lexical)", nine.Version, ten.Version)
+	}
+}
+
+func TestVersionStringMarshalRoundTrip(t *testing.T) {
+	prevMode, prevSimple := VersionMarshalMode, SimpleStringUnmarshalForVersionString
+	defer func() { VersionMarshalMode, SimpleStringUnmarshalForVersionString = prevMode, prevSimple }()
+	SimpleStringUnmarshalForVersionString = true
+
+	for _, mode := range []MarshalMode{Full, Compact} {
+		VersionMarshalMode = mode
+		v := mustVersion(t, "1.2.3-rc.1+build5")
+		b, err := json.Marshal(v)
+		if err != nil {
+			t.Fatalf("mode %v: Marshal: %v", mode, err)
+		}
+		if mode == Compact && string(b) != `"1.2.3-rc.1+build5"` {
+			t.Errorf("Compact mode: got %s, want canonical string", b)
+		}
+		var v2 VersionString
+		if err := json.Unmarshal(b, &v2); err != nil {
+			t.Fatalf("mode %v: Unmarshal(%s): %v", mode, b, err)
+		}
+		if !v.Equal(v2) || v.Version != v2.Version {
+			t.Errorf("mode %v: round trip mismatch: %+v vs %+v", mode, v, v2)
+		}
+	}
+}
+
+func TestVersionStringsMarshalRoundTrip(t *testing.T) {
+	prevMode, prevSimple := VersionMarshalMode, SimpleStringUnmarshalForVersionString
+	defer func() { VersionMarshalMode, SimpleStringUnmarshalForVersionString = prevMode, prevSimple }()
+	SimpleStringUnmarshalForVersionString = true
+
+	for _, mode := range []MarshalMode{Full, Compact} {
+		VersionMarshalMode = mode
+		var vs VersionStrings
+		if err := vs.FromString("1.2.3.4;0.1.2.6"); err != nil {
+			t.Fatalf("FromString: %v", err)
+		}
+		b, err := json.Marshal(vs)
+		if err != nil {
+			t.Fatalf("mode %v: Marshal: %v", mode, err)
+		}
+		if mode == Compact && string(b) != `"1.2.3.4;0.1.2.6"` {
+			t.Errorf("Compact mode: got %s, want semicolon-joined string", b)
+		}
+		var vs2 VersionStrings
+		if err := json.Unmarshal(b, &vs2); err != nil {
+			t.Fatalf("mode %v: Unmarshal(%s): %v", mode, b, err)
+		}
+		if len(vs) != len(vs2) {
+			t.Fatalf("mode %v: round trip length mismatch: %d vs %d", mode, len(vs), len(vs2))
+		}
+		for i := range vs {
+			if !vs[i].Equal(vs2[i]) {
+				t.Errorf("mode %v: element #%d round trip mismatch: %+v vs %+v", mode, i, vs[i], vs2[i])
+			}
+		}
+	}
+}
+
+func TestDecodeVersionStream(t *testing.T) {
+	prev := SimpleStringUnmarshalForVersionString
+	defer func() { SimpleStringUnmarshalForVersionString = prev }()
+	SimpleStringUnmarshalForVersionString = true
+
+	var got []string
+	err := DecodeVersionStream(strings.NewReader(`["1.2.3","0.1.2-rc.1"]`), func(v VersionString) error {
+		got = append(got, v.Version)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("DecodeVersionStream: %v", err)
+	}
+	want := []string{"1.2.3", "0.1.2-rc.1"}
+	if len(got) != len(want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("element #%d = %q, want %q", i, got[i], want[i])
+		}
+	}
+}
+
+func TestDecodeVersionStreamDoesNotLeakFieldsAcrossElements(t *testing.T) {
+	var got []VersionString
+	err := DecodeVersionStream(strings.NewReader(
+		`[{"Version":"1.2.3","Fields":[1,2,3],"NumCoreFields":3,"Build":"leaky"},`+
+			`{"Version":"4.5.6","Fields":[4,5,6],"NumCoreFields":3}]`),
+		func(v VersionString) error {
+			got = append(got, v)
+			return nil
+		})
+	if err != nil {
+		t.Fatalf("DecodeVersionStream: %v", err)
+	}
+	if len(got) != 2 {
+		t.Fatalf("got %d callback invocations, want 2", len(got))
+	}
+	if got[1].Build != "" {
+		t.Errorf("element #1 has no Build key in its JSON but decoded with Build = %q (leaked from element #0)", got[1].Build)
+	}
+}
+
+func TestDecodeVersionStreamEmpty(t *testing.T) {
+	var calls int
+	err := DecodeVersionStream(strings.NewReader(`[]`), func(v VersionString) error {
+		calls++
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("DecodeVersionStream: %v", err)
+	}
+	if calls != 0 {
+		t.Errorf("expected no callback invocations for an empty array, got %d", calls)
+	}
+}
+
+func TestDecodeVersionStreamCallbackError(t *testing.T) {
+	prev := SimpleStringUnmarshalForVersionString
+	defer func() { SimpleStringUnmarshalForVersionString = prev }()
+	SimpleStringUnmarshalForVersionString = true
+
+	sentinel := fmt.Errorf("stop")
+	err := DecodeVersionStream(strings.NewReader(`["1.2.3","0.1.2"]`), func(v VersionString) error {
+		return sentinel
+	})
+	if err == nil || !errors.Is(err, sentinel) {
+		t.Fatalf("expected an error wrapping the callback's sentinel, got %v", err)
+	}
+}
+
+func TestDecodeVersionStreamBadDelimiter(t *testing.T) {
+	err := DecodeVersionStream(strings.NewReader(`{"1.2.3"}`), func(v VersionString) error {
+		return nil
+	})
+	if err == nil {
+		t.Fatal("expected an error for a non-array top-level value")
+	}
+}
+
+func TestVersionFieldJSONRoundTripOverflow(t *testing.T) {
+	var v VersionString
+	if err := v.FromString("1.99999999999999999999.3a"); err != nil {
+		t.Fatalf("FromString: %v", err)
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	var v2 VersionString
+	if err := json.Unmarshal(b, &v2); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	if !v.Equal(v2) {
+		t.Errorf("round trip mismatch: %+v vs %+v", v, v2)
+	}
+	if v2.Fields[1].BigValue == nil || v2.Fields[1].BigValue.Cmp(big.NewInt(0)) == 0 {
+		t.Errorf("expected field #1 to round-trip through BigValue, got %+v", v2.Fields[1])
+	}
+}
+
+func mustVersion(t *testing.T, s string) VersionString {
+	t.Helper()
+	var v VersionString
+	if err := v.FromString(s); err != nil {
+		t.Fatalf("FromString(%q): %v", s, err)
+	}
+	return v
+}
+
+func TestCompareSemVerPrecedence(t *testing.T) {
+	// SemVer 2.0.0 spec 11.3 example precedence chain, lowest to highest.
+	order := []string{
+		"1.0.0-alpha",
+		"1.0.0-alpha.1",
+		"1.0.0-alpha.beta",
+		"1.0.0-beta",
+		"1.0.0-beta.2",
+		"1.0.0-beta.11",
+		"1.0.0-rc.1",
+		"1.0.0",
+	}
+	for i := 1; i < len(order); i++ {
+		lo, hi := mustVersion(t, order[i-1]), mustVersion(t, order[i])
+		if !lo.Less(hi) {
+			t.Errorf("expected %q < %q", order[i-1], order[i])
+		}
+		if lo.Equal(hi) {
+			t.Errorf("%q and %q should not compare equal", order[i-1], order[i])
+		}
+		if hi.Compare(lo) <= 0 {
+			t.Errorf("expected %q > %q", order[i], order[i-1])
+		}
+	}
+}
+
+func TestCompareBuildMetadataIgnored(t *testing.T) {
+	a := mustVersion(t, "1.2.3+build1")
+	b := mustVersion(t, "1.2.3+build2")
+	if !a.Equal(b) {
+		t.Errorf("build metadata should be ignored for ordering: %q vs %q", a.Version, b.Version)
+	}
+}
+
+func TestCompareOrderedVersion(t *testing.T) {
+	a := mustVersion(t, "1.10.0")
+	b := mustVersion(t, "1.9.0")
+	if !b.Less(a) {
+		t.Fatalf("expected numeric comparison to put %q before %q", b.Version, a.Version)
+	}
+	a.OrderedVersion, b.OrderedVersion = true, true
+	if !a.Less(b) {
+		t.Errorf("expected lexical comparison to put %q before %q when OrderedVersion is set", a.Version, b.Version)
+	}
+}
+
+func TestVersionStringsSort(t *testing.T) {
+	vs := VersionStrings{
+		mustVersion(t, "1.2.0"),
+		mustVersion(t, "1.0.0-rc.1"),
+		mustVersion(t, "1.0.0"),
+		mustVersion(t, "0.9.9"),
+	}
+	vs.Sort()
+	got := make([]string, len(vs))
+	for i, v := range vs {
+		got[i] = v.Version
+	}
+	want := []string{"0.9.9", "1.0.0-rc.1", "1.0.0", "1.2.0"}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("Sort() = %v, want %v", got, want)
+		}
+	}
+}